@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/golang/glog"
+
+	"kubernetes-ldap/token"
+)
+
+// JWKSHandler serves the current signing KeySet's public keys in JWK Set
+// form so downstream services can verify issued tokens independently of
+// this service, without sharing the /authenticate webhook.
+type JWKSHandler struct {
+	KeySet *token.KeySet
+}
+
+func (h *JWKSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jwks, err := h.KeySet.JWKS()
+	if err != nil {
+		glog.Errorf("failed to build JWKS: %v", err)
+		http.Error(w, "failed to build JWKS", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, jwks)
+}
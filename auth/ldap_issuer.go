@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/golang/glog"
+
+	"kubernetes-ldap/ldap"
+	"kubernetes-ldap/token"
+)
+
+// LDAPAuthenticator authenticates a username/password pair against an LDAP
+// directory and returns the matching entry. ldap.Client implements this.
+type LDAPAuthenticator interface {
+	Authenticate(username, password string) (*ldap.UserEntry, error)
+}
+
+// LDAPTokenIssuer authenticates a user against LDAP and, on success, issues
+// a short-lived access token plus an opaque refresh token.
+type LDAPTokenIssuer struct {
+	LDAPAuthenticator     LDAPAuthenticator
+	TokenSigner           token.Signer
+	RefreshIssuer         *token.RefreshIssuer
+	GroupFilter           string
+	ExpireTime            int // access token lifetime, in minutes
+	UsernameLDAPAttribute string
+
+	// Issuer and Audience, if set, are stamped as the "iss"/"aud" claims of
+	// every access token, for OIDC-mode deployments.
+	Issuer   string
+	Audience string
+}
+
+// issuedTokens is the JSON body returned from /ldapAuth and /refresh.
+type issuedTokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+func (h *LDAPTokenIssuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		http.Error(w, "missing basic auth credentials", http.StatusUnauthorized)
+		return
+	}
+
+	entry, err := h.LDAPAuthenticator.Authenticate(username, password)
+	if err != nil {
+		glog.Infof("LDAP authentication failed for %q: %v", username, err)
+		http.Error(w, "authentication failed", http.StatusUnauthorized)
+		return
+	}
+
+	subject := firstAttribute(entry, h.UsernameLDAPAttribute)
+	if subject == "" {
+		subject = username
+	}
+	groups := filterGroups(h.GroupFilter, entry.Attributes["memberOf"])
+
+	accessToken, err := h.signAccessToken(subject, groups)
+	if err != nil {
+		glog.Errorf("failed to sign access token for %q: %v", subject, err)
+		http.Error(w, "failed to issue token", http.StatusInternalServerError)
+		return
+	}
+
+	resp := issuedTokens{Token: accessToken}
+	if h.RefreshIssuer != nil {
+		refreshToken, err := h.RefreshIssuer.Issue(subject, groups)
+		if err != nil {
+			glog.Errorf("failed to issue refresh token for %q: %v", subject, err)
+			http.Error(w, "failed to issue refresh token", http.StatusInternalServerError)
+			return
+		}
+		resp.RefreshToken = refreshToken
+	}
+
+	writeJSON(w, resp)
+}
+
+func (h *LDAPTokenIssuer) signAccessToken(subject string, groups []string) (string, error) {
+	now := time.Now()
+	return h.TokenSigner.Sign(&token.AuthToken{
+		Issuer:    h.Issuer,
+		Subject:   subject,
+		Audience:  h.Audience,
+		Groups:    groups,
+		Type:      token.TokenTypeAccess,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(time.Duration(h.ExpireTime) * time.Minute),
+	})
+}
+
+// filterGroups returns the subset of groups matching pattern, or all of
+// groups unchanged if pattern is empty or fails to compile.
+func filterGroups(pattern string, groups []string) []string {
+	if pattern == "" {
+		return groups
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		glog.Errorf("invalid group filter %q: %v", pattern, err)
+		return groups
+	}
+	filtered := make([]string, 0, len(groups))
+	for _, g := range groups {
+		if re.MatchString(g) {
+			filtered = append(filtered, g)
+		}
+	}
+	return filtered
+}
+
+func firstAttribute(entry *ldap.UserEntry, name string) string {
+	values := entry.Attributes[name]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/golang/glog"
+
+	"kubernetes-ldap/token"
+)
+
+// RefreshHandler exchanges a valid refresh token for a new access token,
+// rotating the refresh token on every use.
+type RefreshHandler struct {
+	RefreshIssuer *token.RefreshIssuer
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+func (h *RefreshHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := &refreshRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "missing refreshToken", http.StatusBadRequest)
+		return
+	}
+
+	accessToken, newRefreshToken, err := h.RefreshIssuer.Refresh(req.RefreshToken)
+	if err != nil {
+		glog.Infof("refresh token exchange failed: %v", err)
+		http.Error(w, "invalid or expired refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	writeJSON(w, issuedTokens{Token: accessToken, RefreshToken: newRefreshToken})
+}
+
+// RevokeHandler invalidates a refresh token so it can no longer be
+// exchanged for access tokens.
+type RevokeHandler struct {
+	RefreshIssuer *token.RefreshIssuer
+}
+
+func (h *RevokeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := &refreshRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "missing refreshToken", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.RefreshIssuer.Revoke(req.RefreshToken); err != nil {
+		glog.Errorf("failed to revoke refresh token: %v", err)
+		http.Error(w, "failed to revoke token", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+
+	"kubernetes-ldap/ssh"
+)
+
+// LDAPSSHCertIssuer authenticates a user against LDAP and, on success,
+// signs a short-lived OpenSSH user certificate for a public key the caller
+// submits alongside their credentials.
+type LDAPSSHCertIssuer struct {
+	LDAPAuthenticator     LDAPAuthenticator
+	CA                    *ssh.CertificateAuthority
+	GroupFilter           string
+	ValidFor              time.Duration
+	UsernameLDAPAttribute string
+}
+
+type sshCertRequest struct {
+	PublicKey string `json:"publicKey"`
+}
+
+func (h *LDAPSSHCertIssuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		http.Error(w, "missing basic auth credentials", http.StatusUnauthorized)
+		return
+	}
+
+	req := &sshCertRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil || req.PublicKey == "" {
+		http.Error(w, "missing publicKey", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.LDAPAuthenticator.Authenticate(username, password)
+	if err != nil {
+		glog.Infof("LDAP authentication failed for %q: %v", username, err)
+		http.Error(w, "authentication failed", http.StatusUnauthorized)
+		return
+	}
+
+	principal := firstAttribute(entry, h.UsernameLDAPAttribute)
+	if principal == "" {
+		principal = username
+	}
+	groups := filterGroups(h.GroupFilter, entry.Attributes["memberOf"])
+
+	cert, err := h.CA.IssueUserCertificate([]byte(req.PublicKey), principal, groups, h.ValidFor)
+	if err != nil {
+		glog.Errorf("failed to issue SSH certificate for %q: %v", principal, err)
+		http.Error(w, "failed to issue SSH certificate", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(cert)
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/golang/glog"
+
+	"kubernetes-ldap/token"
+)
+
+// tokenReview mirrors the subset of authentication.k8s.io TokenReview that
+// this webhook reads and writes.
+type tokenReview struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Spec       tokenReviewSpec   `json:"spec,omitempty"`
+	Status     tokenReviewStatus `json:"status,omitempty"`
+}
+
+type tokenReviewSpec struct {
+	Token string `json:"token"`
+}
+
+type tokenReviewStatus struct {
+	Authenticated bool         `json:"authenticated"`
+	User          tokenSubject `json:"user,omitempty"`
+	Error         string       `json:"error,omitempty"`
+}
+
+type tokenSubject struct {
+	Username string   `json:"username"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// TokenWebhook implements the Kubernetes TokenReview webhook protocol,
+// authenticating requests by verifying the bearer token against a
+// token.Verifier.
+type TokenWebhook struct {
+	TokenVerifier token.Verifier
+}
+
+// NewTokenWebhook returns a TokenWebhook backed by verifier.
+func NewTokenWebhook(verifier token.Verifier) *TokenWebhook {
+	return &TokenWebhook{TokenVerifier: verifier}
+}
+
+func (w *TokenWebhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	review := &tokenReview{}
+	if err := json.NewDecoder(r.Body).Decode(review); err != nil {
+		http.Error(rw, "failed to decode TokenReview", http.StatusBadRequest)
+		return
+	}
+
+	review.Status = w.review(review.Spec.Token)
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(review); err != nil {
+		glog.Errorf("failed to encode TokenReview response: %v", err)
+	}
+}
+
+func (w *TokenWebhook) review(rawToken string) tokenReviewStatus {
+	authToken, err := w.TokenVerifier.Verify(rawToken)
+	if err != nil {
+		glog.Infof("token verification failed: %v", err)
+		return tokenReviewStatus{Authenticated: false, Error: "invalid token"}
+	}
+
+	if authToken.Type != token.TokenTypeAccess {
+		return tokenReviewStatus{Authenticated: false, Error: "not an access token"}
+	}
+
+	return tokenReviewStatus{
+		Authenticated: true,
+		User: tokenSubject{
+			Username: authToken.Subject,
+			Groups:   authToken.Groups,
+		},
+	}
+}
@@ -2,13 +2,19 @@ package main
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"time"
 	"github.com/golang/glog"
 
 	"kubernetes-ldap/auth"
 	"kubernetes-ldap/ldap"
+	"kubernetes-ldap/oidc"
+	"kubernetes-ldap/ssh"
+	"kubernetes-ldap/tlsconfig"
 	"kubernetes-ldap/token"
 
 	"flag"
@@ -29,11 +35,30 @@ var flUserLoginAttribute = flag.String("ldap-user-attribute", "uid", "LDAP Usern
 var flSearchUserDN = flag.String("ldap-search-user-dn", "", "Search user DN for this app to find users (e.g.: cn=admin,dc=example,dc=com).")
 var flSearchUserPassword = flag.String("ldap-search-user-password", "", "Search user password")
 var flSkipLdapTLSVerification = flag.Bool("ldap-skip-tls-verification", false, "Skip LDAP server TLS verification")
+var flLdapTLSProfile = flag.String("ldap-tls-profile", tlsconfig.ProfileDefaultLDAP, "TLS profile for the LDAP client connection: secure, default, or default-ldap")
 var flGroupFilter = flag.String("group-filter","","Regex to filter group membership")
 var flUsernameAttribute = flag.String("token-username-attribute","mail","LDAP attribute to use for username in token")
 
 // Token options
-var flTokenExpireTime = flag.Int("token-expire-time",12,"Time in hours the issued token is valid")
+var flTokenExpireTime = flag.Int("token-expire-time",15,"Time in minutes the issued access token is valid")
+var flRefreshTokenExpireTime = flag.Duration("refresh-token-expire-time",30*24*time.Hour,"Time the issued refresh token remains usable")
+var flRefreshStore = flag.String("refresh-token-store","memory","Refresh token store backend: memory, file, or redis (redis requires a binary built with the \"redis\" tag)")
+var flRefreshStoreFile = flag.String("refresh-token-store-file","refresh-tokens.json","File used by the file refresh token store")
+var flRefreshStoreRedisAddr = flag.String("refresh-token-store-redis-addr","localhost:6379","host:port of the Redis server used by the redis refresh token store")
+var flRefreshStoreRedisPrefix = flag.String("refresh-token-store-redis-prefix","kubernetes-ldap:refresh:","Key prefix used by the redis refresh token store")
+
+// Signing key options
+var flSigningKeyDir = flag.String("signing-key-dir","signing-keys","Directory holding the signing key set")
+var flSigningKeyRotation = flag.Duration("signing-key-rotation",24*time.Hour,"How often a new active signing key is minted")
+var flSigningKeyRetention = flag.Duration("signing-key-retention",72*time.Hour,"How long a retired signing key is kept around to verify tokens it already signed")
+
+// SSH certificate options
+var flSSHCAKeyFile = flag.String("ssh-ca-key-file", "", "File containing the SSH CA private key used to sign issued user certificates. Enables /sshAuth when set.")
+
+// OIDC options
+var flOIDCIssuerURL = flag.String("oidc-issuer-url", "", "Issuer URL to stamp into issued tokens and serve from /.well-known/openid-configuration. Enables OIDC discovery when set; kube-apiserver can then be pointed at this service via --oidc-issuer-url instead of the TokenReview webhook. Note: kube-apiserver defaults --oidc-signing-algs to RS256, so it must also be passed --oidc-signing-algs=PS512 to accept tokens from this service.")
+var flOIDCAudience = flag.String("oidc-audience", "", "Audience ('aud' claim) to stamp into issued tokens, matching kube-apiserver's --oidc-client-id")
+var flOIDCGroupsClaim = flag.String("oidc-groups-claim", "groups", "JSON claim name issued tokens carry group membership under")
 
 // webhook http(s) server options
 var flServerPort = flag.Uint("port", 4000, "Local port this proxy server will run on")
@@ -42,6 +67,9 @@ var flTLSCertFile = flag.String("tls-cert-file", "",
 	"File containing x509 Certificate for HTTPS.  (CA cert, if any, concatenated after server cert).")
 var flTLSPrivateKeyFile = flag.String("tls-private-key-file", "", "File containing x509 private key matching --tls-cert-file.")
 var flUseTls = flag.Bool("use-tls",true,"Use tls for webhook server")
+var flTLSProfile = flag.String("tls-profile", tlsconfig.ProfileDefault, "TLS profile for the webhook server: secure, default, or default-ldap")
+var flTLSClientCAFile = flag.String("tls-client-ca-file", "", "File containing x509 CA certificates used to verify client certificates from the Kubernetes API server (enables mTLS on the webhook server)")
+var flShutdownTimeout = flag.Duration("shutdown-timeout", 30*time.Second, "How long to wait for in-flight requests to drain on SIGTERM/SIGINT before forcing shutdown")
 
 // other flags
 var flVersion = flag.Bool("version",false,"print version and exit")
@@ -73,27 +101,30 @@ func main() {
 
 	glog.CopyStandardLogTo("INFO")
 
-	keypairFilename := "signing"
-	glog.Info("Generating token singing keypair")
-	if err := token.GenerateKeypair(keypairFilename); err != nil {
-		glog.Errorf("Error generating key pair: %v", err)
+	if *flOIDCGroupsClaim != "" {
+		token.GroupsClaim = *flOIDCGroupsClaim
 	}
 
-	var err error
-	tokenSigner, err := token.NewSigner(keypairFilename)
+	keySet, err := token.LoadOrCreateKeySet(*flSigningKeyDir, *flSigningKeyRotation, *flSigningKeyRetention)
 	if err != nil {
-		glog.Errorf("Error creating token issuer: %v", err)
+		glog.Fatalf("Error loading signing key set: %v", err)
 	}
+	stopRotation := make(chan struct{})
+	go keySet.StartRotation(*flSigningKeyRotation, stopRotation)
 
-	tokenVerifier, err := token.NewVerifier(keypairFilename)
+	tokenSigner, err := token.NewSignerFromKeySet(keySet)
 	if err != nil {
-		glog.Errorf("Error creating token verifier: %v", err)
+		glog.Errorf("Error creating token issuer: %v", err)
 	}
 
-	ldapTLSConfig := &tls.Config{
-		ServerName:         *flLdapHost,
-		InsecureSkipVerify: *flSkipLdapTLSVerification,
+	tokenVerifier := token.NewVerifierFromKeySet(keySet)
+
+	ldapTLSConfig, err := tlsconfig.New(*flLdapTLSProfile)
+	if err != nil {
+		glog.Fatalf("Error building LDAP TLS config: %v", err)
 	}
+	ldapTLSConfig.ServerName = *flLdapHost
+	ldapTLSConfig.InsecureSkipVerify = *flSkipLdapTLSVerification
 
 	ldapClient := &ldap.Client{
 		BaseDN:             *flBaseDN,
@@ -106,6 +137,33 @@ func main() {
 		TLSConfig:          ldapTLSConfig,
 	}
 
+	var refreshStore token.RefreshStore
+	switch *flRefreshStore {
+	case "file":
+		refreshStore, err = token.NewFileStore(*flRefreshStoreFile)
+		if err != nil {
+			glog.Fatalf("Error opening refresh token store %q: %v", *flRefreshStoreFile, err)
+		}
+	case "memory":
+		refreshStore = token.NewMemoryStore()
+	case "redis":
+		refreshStore, err = token.NewRedisStoreFromAddr(*flRefreshStoreRedisAddr, *flRefreshStoreRedisPrefix)
+		if err != nil {
+			glog.Fatalf("Error connecting to --refresh-token-store-redis-addr %q: %v", *flRefreshStoreRedisAddr, err)
+		}
+	default:
+		glog.Fatalf("Unknown --refresh-token-store %q: expected memory, file, or redis", *flRefreshStore)
+	}
+
+	refreshIssuer := token.NewRefreshIssuer(
+		refreshStore,
+		tokenSigner,
+		*flRefreshTokenExpireTime,
+		time.Duration(*flTokenExpireTime)*time.Minute,
+	)
+	refreshIssuer.Issuer = *flOIDCIssuerURL
+	refreshIssuer.Audience = *flOIDCAudience
+
 	publicRouter := http.NewServeMux()
 	sslRouter := http.NewServeMux()
 
@@ -114,23 +172,81 @@ func main() {
 	ldapTokenIssuer := &auth.LDAPTokenIssuer{
 		LDAPAuthenticator: 	ldapClient,
 		TokenSigner:       	tokenSigner,
+		RefreshIssuer:      	refreshIssuer,
 		GroupFilter:       	*flGroupFilter,
 		ExpireTime:        	*flTokenExpireTime,
 		UsernameLDAPAttribute: 	*flUsernameAttribute,
+		Issuer:                 *flOIDCIssuerURL,
+		Audience:               *flOIDCAudience,
 	}
 
 	// Endpoint for authenticating with token
-	publicRouter.Handle("/authenticate", webhook)
+	sslRouter.Handle("/authenticate", webhook)
 
 	// Endpoint for token issuance after LDAP auth
-	publicRouter.Handle("/ldapAuth", ldapTokenIssuer)
+	sslRouter.Handle("/ldapAuth", ldapTokenIssuer)
+
+	// Endpoint for exchanging a refresh token for a new access token
+	sslRouter.Handle("/refresh", &auth.RefreshHandler{RefreshIssuer: refreshIssuer})
+
+	// Endpoint for revoking a refresh token
+	sslRouter.Handle("/revoke", &auth.RevokeHandler{RefreshIssuer: refreshIssuer})
+
+	// Endpoint publishing the current signing keys for independent
+	// verification. Served on the plaintext publicRouter, not sslRouter:
+	// kube-apiserver's OIDC/JWKS fetch never presents a client certificate,
+	// so folding this onto a --tls-client-ca-file-enforcing listener would
+	// make it unreachable whenever mTLS is enabled.
+	jwksHandler := &auth.JWKSHandler{KeySet: keySet}
+	publicRouter.Handle("/.well-known/jwks.json", jwksHandler)
+	publicRouter.Handle("/jwks", jwksHandler)
 
-	// Endpoint for healthz on ssl port
-	publicRouter.HandleFunc("/healthz", healthz)
+	// OIDC discovery, so kube-apiserver can be configured with
+	// --oidc-issuer-url pointed at this service instead of the TokenReview
+	// webhook. Served alongside JWKS on publicRouter for the same reason.
+	if *flOIDCIssuerURL != "" {
+		publicRouter.Handle("/.well-known/openid-configuration", &oidc.DiscoveryHandler{
+			IssuerURL:   *flOIDCIssuerURL,
+			GroupsClaim: *flOIDCGroupsClaim,
+		})
+	}
 
-	TLSConfig := &tls.Config{
-		// Change default from SSLv3 to TLSv1.0 (because of POODLE vulnerability)
-		MinVersion: tls.VersionTLS10,
+	// Endpoint for issuing LDAP-gated SSH user certificates, if configured
+	if *flSSHCAKeyFile != "" {
+		sshCA, err := ssh.LoadCA(*flSSHCAKeyFile)
+		if err != nil {
+			glog.Fatalf("Error loading --ssh-ca-key-file: %v", err)
+		}
+		sshCertIssuer := &auth.LDAPSSHCertIssuer{
+			LDAPAuthenticator:     ldapClient,
+			CA:                    sshCA,
+			GroupFilter:           *flGroupFilter,
+			ValidFor:              time.Duration(*flTokenExpireTime) * time.Minute,
+			UsernameLDAPAttribute: *flUsernameAttribute,
+		}
+		sslRouter.Handle("/sshAuth", sshCertIssuer)
+	}
+
+	// Endpoint for the readiness probe. Served in the clear on --health-port
+	// so kubelet doesn't need client certs to poll it.
+	health := &healthzHandler{}
+	publicRouter.Handle("/healthz", health)
+
+	TLSConfig, err := tlsconfig.New(*flTLSProfile)
+	if err != nil {
+		glog.Fatalf("Error building webhook TLS config: %v", err)
+	}
+	if *flTLSClientCAFile != "" {
+		caCert, err := ioutil.ReadFile(*flTLSClientCAFile)
+		if err != nil {
+			glog.Fatalf("Error reading --tls-client-ca-file: %v", err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caCert) {
+			glog.Fatalf("No certificates found in --tls-client-ca-file %q", *flTLSClientCAFile)
+		}
+		TLSConfig.ClientCAs = clientCAs
+		TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
 	}
 
 	//setting up servers
@@ -141,23 +257,27 @@ func main() {
 	}
 
 	publicServer := &http.Server{
-		Addr: fmt.Sprintf(":%d", *flhHealthzPort),
+		Addr:    fmt.Sprintf(":%d", *flhHealthzPort),
 		Handler: publicRouter,
-		TLSConfig: TLSConfig,
 	}
 
-	// starting public server
-	go publicServer.ListenAndServe()
-	glog.Infof("Serving /healthz on %s", fmt.Sprintf(":%d", *flhHealthzPort))
-	// starting api server
-	glog.Infof("Serving /authenticate on %s", fmt.Sprintf(":%d", *flhHealthzPort))
-	glog.Infof("Serving /ldapAuth on %s", fmt.Sprintf(":%d", *flhHealthzPort))
+	glog.Infof("Serving /healthz, /jwks, and OIDC discovery on %s, /authenticate and /ldapAuth on %s", fmt.Sprintf(":%d", *flhHealthzPort), fmt.Sprintf(":%d", *flServerPort))
+
+	sslStart := sslServer.ListenAndServe
 	if *flUseTls {
-		glog.Fatal(sslServer.ListenAndServeTLS(*flTLSCertFile, *flTLSPrivateKeyFile))
-	} else {
-		glog.Fatal(sslServer.ListenAndServe())
+		sslStart = func() error {
+			return sslServer.ListenAndServeTLS(*flTLSCertFile, *flTLSPrivateKeyFile)
+		}
+	}
+
+	servers := []managedServer{
+		{server: publicServer, start: publicServer.ListenAndServe},
+		{server: sslServer, start: sslStart},
 	}
 
+	if err := serve(servers, health, *flShutdownTimeout); err != nil {
+		glog.Fatal(err)
+	}
 }
 
 func requireFlag(flagName string, flagValue *string) {
@@ -166,8 +286,3 @@ func requireFlag(flagName string, flagValue *string) {
 		os.Exit(1)
 	}
 }
-
-func healthz(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("ok"))
-}
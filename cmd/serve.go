@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/golang/glog"
+	"golang.org/x/sync/errgroup"
+
+	"kubernetes-ldap/sdnotify"
+)
+
+// healthzHandler answers readiness probes, flipping to 503 once shutdown
+// begins so a load balancer stops sending new traffic before the listeners
+// actually close.
+type healthzHandler struct {
+	shuttingDown int32
+}
+
+func (h *healthzHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(&h.shuttingDown) != 0 {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+func (h *healthzHandler) setShuttingDown() {
+	atomic.StoreInt32(&h.shuttingDown, 1)
+}
+
+// managedServer pairs an *http.Server with the blocking call that starts it,
+// since the sslServer's start varies with --use-tls.
+type managedServer struct {
+	server *http.Server
+	start  func() error
+}
+
+// serve runs every managedServer concurrently, notifies systemd that
+// startup finished, and on SIGTERM/SIGINT flips health to unready, notifies
+// systemd that shutdown began, and gives every server up to shutdownTimeout
+// to drain in-flight requests before returning.
+func serve(servers []managedServer, health *healthzHandler, shutdownTimeout time.Duration) error {
+	var g errgroup.Group
+	failed := make(chan error, len(servers))
+	for _, s := range servers {
+		s := s
+		g.Go(func() error {
+			if err := s.start(); err != nil && err != http.ErrServerClosed {
+				failed <- err
+				return err
+			}
+			return nil
+		})
+	}
+	done := make(chan error, 1)
+	go func() { done <- g.Wait() }()
+
+	if err := sdnotify.Notify(sdnotify.Ready); err != nil {
+		glog.Warningf("sd_notify READY failed: %v", err)
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+
+	select {
+	case <-sigCh:
+	case err := <-done:
+		return err
+	case err := <-failed:
+		// A server exited on its own (most likely a startup failure such as
+		// a bad --tls-cert-file or the port already being bound) before any
+		// shutdown signal arrived. Report it immediately instead of hanging
+		// until SIGTERM, since the other listeners staying up would
+		// otherwise make the process look healthy.
+		return err
+	}
+
+	glog.Info("Received shutdown signal, draining connections")
+	health.setShuttingDown()
+	if err := sdnotify.Notify(sdnotify.Stopping); err != nil {
+		glog.Warningf("sd_notify STOPPING failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, s := range servers {
+		if err := s.server.Shutdown(ctx); err != nil {
+			glog.Errorf("error shutting down %s: %v", s.server.Addr, err)
+		}
+	}
+
+	return <-done
+}
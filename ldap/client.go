@@ -0,0 +1,79 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/golang/glog"
+	ldapv2 "gopkg.in/ldap.v2"
+)
+
+// UserEntry is the subset of an LDAP directory entry this package resolves
+// during authentication: the user's DN and their full attribute set.
+type UserEntry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// Client authenticates users against an LDAP (or Active Directory) server.
+type Client struct {
+	BaseDN             string
+	LdapServer         string
+	LdapPort           uint
+	UseInsecure        bool
+	UserLoginAttribute string
+	SearchUserDN       string
+	SearchUserPassword string
+	TLSConfig          *tls.Config
+}
+
+func (c *Client) connect() (*ldapv2.Conn, error) {
+	addr := fmt.Sprintf("%s:%d", c.LdapServer, c.LdapPort)
+	if c.UseInsecure {
+		return ldapv2.Dial("tcp", addr)
+	}
+	return ldapv2.DialTLS("tcp", addr, c.TLSConfig)
+}
+
+// Authenticate binds as the configured search user, looks up the entry for
+// username, then re-binds as that entry's DN using password to verify the
+// credential. It returns the user's full attribute set on success.
+func (c *Client) Authenticate(username, password string) (*UserEntry, error) {
+	conn, err := c.connect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LDAP server: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.Bind(c.SearchUserDN, c.SearchUserPassword); err != nil {
+		return nil, fmt.Errorf("failed to bind search user: %v", err)
+	}
+
+	searchRequest := ldapv2.NewSearchRequest(
+		c.BaseDN,
+		ldapv2.ScopeWholeSubtree, ldapv2.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf("(%s=%s)", c.UserLoginAttribute, ldapv2.EscapeFilter(username)),
+		[]string{},
+		nil,
+	)
+
+	result, err := conn.Search(searchRequest)
+	if err != nil {
+		return nil, fmt.Errorf("LDAP search failed: %v", err)
+	}
+	if len(result.Entries) != 1 {
+		return nil, fmt.Errorf("expected exactly one entry for %q, got %d", username, len(result.Entries))
+	}
+
+	entry := result.Entries[0]
+	if err := conn.Bind(entry.DN, password); err != nil {
+		glog.Infof("LDAP bind failed for user %q", username)
+		return nil, fmt.Errorf("invalid credentials")
+	}
+
+	attrs := make(map[string][]string, len(entry.Attributes))
+	for _, a := range entry.Attributes {
+		attrs[a.Name] = a.Values
+	}
+	return &UserEntry{DN: entry.DN, Attributes: attrs}, nil
+}
@@ -0,0 +1,45 @@
+// Package oidc lets this service double as an OIDC provider that
+// kube-apiserver can consume directly via its built-in
+// --oidc-issuer-url/--oidc-client-id flags, as an alternative to the
+// TokenReview webhook.
+package oidc
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/golang/glog"
+)
+
+// discoveryDocument is the subset of OpenID Connect Discovery 1.0 that
+// kube-apiserver's OIDC authenticator reads.
+type discoveryDocument struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+	ClaimsSupported                  []string `json:"claims_supported"`
+}
+
+// DiscoveryHandler serves /.well-known/openid-configuration.
+type DiscoveryHandler struct {
+	IssuerURL   string
+	GroupsClaim string
+}
+
+func (h *DiscoveryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	doc := discoveryDocument{
+		Issuer:                           h.IssuerURL,
+		JWKSURI:                          h.IssuerURL + "/jwks",
+		ResponseTypesSupported:           []string{"id_token"},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: []string{"PS512"},
+		ClaimsSupported:                  []string{"iss", "sub", "aud", "iat", "exp", h.GroupsClaim},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		glog.Errorf("failed to encode OIDC discovery document: %v", err)
+	}
+}
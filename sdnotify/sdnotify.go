@@ -0,0 +1,35 @@
+// Package sdnotify sends systemd readiness/status notifications over the
+// NOTIFY_SOCKET datagram socket, without requiring cgo or libsystemd.
+package sdnotify
+
+import (
+	"net"
+	"os"
+)
+
+// Ready is the state string that tells systemd the service finished
+// starting up.
+const Ready = "READY=1"
+
+// Stopping is the state string that tells systemd the service is beginning
+// a graceful shutdown.
+const Stopping = "STOPPING=1"
+
+// Notify sends state to the socket named by $NOTIFY_SOCKET. If the
+// environment variable is unset (the common case outside of systemd, e.g.
+// under Kubernetes without a sidecar), Notify is a silent no-op.
+func Notify(state string) error {
+	socketPath := os.Getenv("NOTIFY_SOCKET")
+	if socketPath == "" {
+		return nil
+	}
+
+	conn, err := net.Dial("unixgram", socketPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
@@ -0,0 +1,90 @@
+package ssh
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func writeRSAPrivateKey(t *testing.T, path string) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	if err := ioutil.WriteFile(path, pemBytes, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return key
+}
+
+func TestCertificateAuthorityIssueUserCertificateRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	caKeyPath := filepath.Join(dir, "ca")
+	caKey := writeRSAPrivateKey(t, caKeyPath)
+
+	ca, err := LoadCA(caKeyPath)
+	if err != nil {
+		t.Fatalf("LoadCA: %v", err)
+	}
+
+	userKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	userPub, err := ssh.NewPublicKey(&userKey.PublicKey)
+	if err != nil {
+		t.Fatalf("NewPublicKey: %v", err)
+	}
+
+	certBytes, err := ca.IssueUserCertificate(ssh.MarshalAuthorizedKey(userPub), "alice", []string{"admins"}, time.Hour)
+	if err != nil {
+		t.Fatalf("IssueUserCertificate: %v", err)
+	}
+
+	parsed, _, _, _, err := ssh.ParseAuthorizedKey(certBytes)
+	if err != nil {
+		t.Fatalf("ParseAuthorizedKey: %v", err)
+	}
+	cert, ok := parsed.(*ssh.Certificate)
+	if !ok {
+		t.Fatalf("ParseAuthorizedKey returned %T, want *ssh.Certificate", parsed)
+	}
+
+	if cert.CertType != ssh.UserCert {
+		t.Errorf("CertType = %v, want ssh.UserCert", cert.CertType)
+	}
+	if cert.KeyId != "alice" {
+		t.Errorf("KeyId = %q, want %q", cert.KeyId, "alice")
+	}
+	if len(cert.ValidPrincipals) != 1 || cert.ValidPrincipals[0] != "alice" {
+		t.Errorf("ValidPrincipals = %v, want [alice]", cert.ValidPrincipals)
+	}
+	if _, ok := cert.Permissions.Extensions["groups@kubernetes-ldap.io=admins"]; !ok {
+		t.Error("certificate is missing the groups@kubernetes-ldap.io=admins extension")
+	}
+
+	caSigner, err := ssh.NewSignerFromKey(caKey)
+	if err != nil {
+		t.Fatalf("NewSignerFromKey: %v", err)
+	}
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return string(auth.Marshal()) == string(caSigner.PublicKey().Marshal())
+		},
+	}
+	if err := checker.CheckCert("alice", cert); err != nil {
+		t.Errorf("CheckCert: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Package tlsconfig provides named tls.Config profiles so operators can
+// pick a security/compatibility tradeoff by name instead of hand-tuning
+// cipher suites and versions in every place a TLS listener or dialer is
+// constructed.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// Profile names accepted by --tls-profile and --ldap-tls-profile.
+const (
+	// ProfileSecure allows only TLS 1.3, deferring cipher choice entirely
+	// to the standard library's AEAD-only 1.3 suite.
+	ProfileSecure = "secure"
+	// ProfileDefault requires TLS 1.2+ with a curated list of modern AEAD
+	// ciphers and curves. Suitable for the webhook listener.
+	ProfileDefault = "default"
+	// ProfileDefaultLDAP requires TLS 1.2+ with a broader cipher list that
+	// still includes CBC suites, for older Active Directory/LDAP servers
+	// that don't offer an AEAD suite.
+	ProfileDefaultLDAP = "default-ldap"
+)
+
+// modernCipherSuites are AEAD suites offered by essentially every TLS 1.2+
+// client and server built in the last several years.
+var modernCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+}
+
+// broadCipherSuites extends modernCipherSuites with CBC-mode suites still
+// commonly required by older Active Directory / OpenLDAP deployments.
+var broadCipherSuites = append(append([]uint16{}, modernCipherSuites...),
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+)
+
+var modernCurves = []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384}
+
+// New returns the named profile's base tls.Config. Callers should treat the
+// result as a template and set fields like ServerName, Certificates, or
+// ClientCAs afterward rather than mutating the profile's shared slices.
+func New(profile string) (*tls.Config, error) {
+	switch profile {
+	case ProfileSecure:
+		return &tls.Config{
+			MinVersion: tls.VersionTLS13,
+		}, nil
+	case ProfileDefault:
+		return &tls.Config{
+			MinVersion:               tls.VersionTLS12,
+			CipherSuites:             modernCipherSuites,
+			CurvePreferences:         modernCurves,
+			PreferServerCipherSuites: true,
+		}, nil
+	case ProfileDefaultLDAP:
+		return &tls.Config{
+			MinVersion:               tls.VersionTLS12,
+			CipherSuites:             broadCipherSuites,
+			CurvePreferences:         modernCurves,
+			PreferServerCipherSuites: true,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown TLS profile %q: expected one of %q, %q, %q", profile, ProfileSecure, ProfileDefault, ProfileDefaultLDAP)
+	}
+}
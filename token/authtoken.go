@@ -0,0 +1,101 @@
+package token
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TokenType distinguishes the kinds of JWS this service issues. Refresh
+// tokens are opaque store-backed strings, not JWTs, so TokenType only ever
+// needs to describe the one kind of token a Verifier sees.
+type TokenType string
+
+// TokenTypeAccess marks a token as usable against the TokenReview webhook.
+const TokenTypeAccess TokenType = "access"
+
+// GroupsClaim names the JSON claim AuthToken serializes group membership
+// under. It defaults to "groups" and is only worth changing to match a
+// consumer that expects a differently-named claim (set via
+// --oidc-groups-claim); every token issued by this process uses whatever
+// value is configured at startup.
+var GroupsClaim = "groups"
+
+// AuthToken is the payload embedded in every JWS this service issues. Its
+// field names follow the OIDC ID token claims (iss/sub/aud/iat/exp) so the
+// same token can both satisfy the TokenReview webhook and be consumed
+// directly by a kube-apiserver configured with --oidc-issuer-url.
+type AuthToken struct {
+	Issuer    string
+	Subject   string
+	Audience  string
+	Groups    []string
+	Type      TokenType
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// Expired reports whether the token's ExpiresAt has passed.
+func (t *AuthToken) Expired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// MarshalJSON emits standard OIDC claim names, plus "type" and the
+// configured GroupsClaim. "iat"/"exp" are encoded as NumericDate (Unix
+// seconds), as required by the JWT spec and expected by kube-apiserver's
+// --oidc-issuer-url authenticator.
+func (t AuthToken) MarshalJSON() ([]byte, error) {
+	claims := map[string]interface{}{
+		"sub":  t.Subject,
+		"type": t.Type,
+		"iat":  t.IssuedAt.Unix(),
+		"exp":  t.ExpiresAt.Unix(),
+	}
+	if t.Issuer != "" {
+		claims["iss"] = t.Issuer
+	}
+	if t.Audience != "" {
+		claims["aud"] = t.Audience
+	}
+	if len(t.Groups) > 0 {
+		claims[GroupsClaim] = t.Groups
+	}
+	return json.Marshal(claims)
+}
+
+// UnmarshalJSON reads standard OIDC claim names, plus "type" and the
+// configured GroupsClaim. "iat"/"exp" are read as NumericDate (Unix
+// seconds), per the JWT spec.
+func (t *AuthToken) UnmarshalJSON(data []byte) error {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{
+		"sub":       &t.Subject,
+		"iss":       &t.Issuer,
+		"aud":       &t.Audience,
+		"type":      &t.Type,
+		GroupsClaim: &t.Groups,
+	}
+	for claim, dest := range fields {
+		if value, ok := raw[claim]; ok {
+			if err := json.Unmarshal(value, dest); err != nil {
+				return err
+			}
+		}
+	}
+
+	for claim, dest := range map[string]*time.Time{"iat": &t.IssuedAt, "exp": &t.ExpiresAt} {
+		value, ok := raw[claim]
+		if !ok {
+			continue
+		}
+		var seconds int64
+		if err := json.Unmarshal(value, &seconds); err != nil {
+			return err
+		}
+		*dest = time.Unix(seconds, 0)
+	}
+	return nil
+}
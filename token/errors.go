@@ -0,0 +1,5 @@
+package token
+
+import "errors"
+
+var errNoPEMData = errors.New("token: failed to decode PEM block")
@@ -0,0 +1,65 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+)
+
+const rsaKeySize = 2048
+
+// LoadPrivateKey parses a PEM-encoded PKCS#1 RSA private key.
+func LoadPrivateKey(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errNoPEMData
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// LoadPublicKey parses a PEM-encoded PKIX RSA public key.
+func LoadPublicKey(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errNoPEMData
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// GenerateKeypair writes a freshly generated RSA keypair to filename+".priv"
+// and filename+".pub" in PEM form. It is idempotent: if both files already
+// exist, it leaves them untouched rather than clobbering material that may
+// already have tokens issued against it.
+func GenerateKeypair(filename string) error {
+	if _, err := os.Stat(filename + ".priv"); err == nil {
+		if _, err := os.Stat(filename + ".pub"); err == nil {
+			return nil
+		}
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeySize)
+	if err != nil {
+		return err
+	}
+
+	privBytes := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	if err := ioutil.WriteFile(filename+".priv", privBytes, 0600); err != nil {
+		return err
+	}
+
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return err
+	}
+	pubBytes := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: pubDER,
+	})
+	return ioutil.WriteFile(filename+".pub", pubBytes, 0644)
+}
@@ -0,0 +1,227 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// KeySet manages a directory of RSA signing keys identified by a stable
+// "kid", rotating in a new active key on a schedule while keeping recently
+// retired keys around long enough to verify tokens they already signed.
+// A KeySet is safe for concurrent use.
+type KeySet struct {
+	dir       string
+	rotation  time.Duration
+	retention time.Duration
+
+	mu       sync.RWMutex
+	keys     map[string]*KeyPair
+	activeID string
+}
+
+// KeyPair is a single RSA signing key together with the identifier used to
+// reference it from a JWS "kid" header and the JWKS endpoint.
+type KeyPair struct {
+	ID         string
+	PrivateKey interface{}
+	CreatedAt  time.Time
+}
+
+// LoadOrCreateKeySet loads every keypair found in dir, generating one if the
+// directory is empty. rotation controls how long a key stays active before a
+// new one is minted; retention controls how long a retired key is kept
+// around to verify tokens signed before it rotated out.
+func LoadOrCreateKeySet(dir string, rotation, retention time.Duration) (*KeySet, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	ks := &KeySet{dir: dir, rotation: rotation, retention: retention, keys: make(map[string]*KeyPair)}
+	if err := ks.load(); err != nil {
+		return nil, err
+	}
+	if len(ks.keys) == 0 {
+		if _, err := ks.newKey(); err != nil {
+			return nil, err
+		}
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) load() error {
+	entries, err := ioutil.ReadDir(ks.dir)
+	if err != nil {
+		return err
+	}
+
+	var newest *KeyPair
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".priv") {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), ".priv")
+		key := &KeyPair{ID: id, CreatedAt: entry.ModTime()}
+
+		data, err := ioutil.ReadFile(filepath.Join(ks.dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		key.PrivateKey, err = LoadPrivateKey(data)
+		if err != nil {
+			return err
+		}
+
+		ks.keys[id] = key
+		if newest == nil || key.CreatedAt.After(newest.CreatedAt) {
+			newest = key
+		}
+	}
+	if newest != nil {
+		ks.activeID = newest.ID
+	}
+	return nil
+}
+
+// newKey generates a fresh keypair, persists it under dir, and makes it the
+// active key. Callers must not hold ks.mu.
+func (ks *KeySet) newKey() (*KeyPair, error) {
+	id, err := randomKeyID()
+	if err != nil {
+		return nil, err
+	}
+	if err := GenerateKeypair(filepath.Join(ks.dir, id)); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(ks.dir, id+".priv"))
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := LoadPrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &KeyPair{ID: id, PrivateKey: privateKey, CreatedAt: time.Now()}
+
+	ks.mu.Lock()
+	ks.keys[id] = key
+	ks.activeID = id
+	ks.mu.Unlock()
+
+	return key, nil
+}
+
+// Active returns the current signing key.
+func (ks *KeySet) Active() *KeyPair {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.keys[ks.activeID]
+}
+
+// Get returns the key with the given kid, including retired-but-not-expired
+// keys, or ok=false if no such key is known.
+func (ks *KeySet) Get(kid string) (*KeyPair, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// RotateIfDue mints a new active key if the current one is older than the
+// configured rotation interval, then prunes any retired key created more
+// than rotation+retention ago.
+func (ks *KeySet) RotateIfDue() error {
+	active := ks.Active()
+	if active != nil && time.Since(active.CreatedAt) < ks.rotation {
+		return nil
+	}
+
+	glog.Infof("Rotating signing key (previous kid=%s)", ks.activeID)
+	if _, err := ks.newKey(); err != nil {
+		return err
+	}
+	ks.pruneExpired()
+	return nil
+}
+
+func (ks *KeySet) pruneExpired() {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	for id, key := range ks.keys {
+		if id == ks.activeID {
+			continue
+		}
+		if time.Since(key.CreatedAt) > ks.rotation+ks.retention {
+			delete(ks.keys, id)
+			os.Remove(filepath.Join(ks.dir, id+".priv"))
+			os.Remove(filepath.Join(ks.dir, id+".pub"))
+			glog.Infof("Retired signing key kid=%s past its retention window", id)
+		}
+	}
+}
+
+// StartRotation checks for a due rotation once per interval until stop is
+// closed. It should be run in its own goroutine.
+func (ks *KeySet) StartRotation(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := ks.RotateIfDue(); err != nil {
+				glog.Errorf("signing key rotation failed: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// JWKS returns the public half of every known key (active and retired) as a
+// JSON Web Key Set, suitable for serving at /.well-known/jwks.json.
+func (ks *KeySet) JWKS() (jose.JSONWebKeySet, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	ids := make([]string, 0, len(ks.keys))
+	for id := range ks.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	jwks := jose.JSONWebKeySet{}
+	for _, id := range ids {
+		key := ks.keys[id]
+		rsaKey, ok := key.PrivateKey.(*rsa.PrivateKey)
+		if !ok {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jose.JSONWebKey{
+			Key:       &rsaKey.PublicKey,
+			KeyID:     id,
+			Algorithm: string(jose.PS512),
+			Use:       "sig",
+		})
+	}
+	return jwks, nil
+}
+
+func randomKeyID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,57 @@
+package token
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeySetRotateIfDueKeepsRetiredKeyWithinRetention(t *testing.T) {
+	ks, err := LoadOrCreateKeySet(t.TempDir(), time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadOrCreateKeySet: %v", err)
+	}
+	original := ks.Active()
+
+	// Force the next RotateIfDue to mint a new key.
+	ks.rotation = 0
+	if err := ks.RotateIfDue(); err != nil {
+		t.Fatalf("RotateIfDue: %v", err)
+	}
+
+	if ks.Active().ID == original.ID {
+		t.Fatal("RotateIfDue did not mint a new active key")
+	}
+
+	key, ok := ks.Get(original.ID)
+	if !ok {
+		t.Fatal("Get did not return the retired key, expected it within its retention window")
+	}
+	if key.ID != original.ID {
+		t.Errorf("Get returned key %q, want %q", key.ID, original.ID)
+	}
+}
+
+func TestKeySetPruneExpiredRemovesKeyPastRetention(t *testing.T) {
+	ks, err := LoadOrCreateKeySet(t.TempDir(), time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("LoadOrCreateKeySet: %v", err)
+	}
+	original := ks.Active()
+
+	ks.rotation = 0
+	if err := ks.RotateIfDue(); err != nil {
+		t.Fatalf("RotateIfDue: %v", err)
+	}
+
+	// Backdate the retired key past rotation+retention so the next prune
+	// sweeps it up.
+	ks.mu.Lock()
+	ks.keys[original.ID].CreatedAt = time.Now().Add(-2 * (ks.rotation + ks.retention + time.Hour))
+	ks.mu.Unlock()
+
+	ks.pruneExpired()
+
+	if _, ok := ks.Get(original.ID); ok {
+		t.Error("Get still returned a key past its retention window, expected it pruned")
+	}
+}
@@ -0,0 +1,112 @@
+package token
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+// RefreshIssuer mints, rotates, and revokes opaque refresh tokens, and uses
+// a Signer to mint the access tokens a refresh exchanges for.
+type RefreshIssuer struct {
+	Store     RefreshStore
+	Signer    Signer
+	Lifetime  time.Duration
+	AccessTTL time.Duration
+
+	// Issuer and Audience, if set, are stamped as the "iss"/"aud" claims of
+	// every access token minted from a refresh, for OIDC-mode deployments.
+	Issuer   string
+	Audience string
+}
+
+// NewRefreshIssuer returns a RefreshIssuer persisting records to store,
+// signing access tokens with signer. lifetime bounds how long a refresh
+// token (and its rotated successors) may be used before re-authentication
+// against LDAP is required; accessTTL is the expiry stamped on each access
+// token minted from a refresh.
+func NewRefreshIssuer(store RefreshStore, signer Signer, lifetime, accessTTL time.Duration) *RefreshIssuer {
+	return &RefreshIssuer{Store: store, Signer: signer, Lifetime: lifetime, AccessTTL: accessTTL}
+}
+
+// Issue mints a new opaque refresh token for username/groups and persists
+// it to the store.
+func (ri *RefreshIssuer) Issue(username string, groups []string) (string, error) {
+	id, err := newOpaqueID()
+	if err != nil {
+		return "", err
+	}
+	record := &RefreshRecord{
+		Username:  username,
+		Groups:    groups,
+		ExpiresAt: time.Now().Add(ri.Lifetime),
+	}
+	if err := ri.Store.Put(id, record); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Refresh exchanges refreshToken for a new access token and a rotated
+// refresh token. The presented refresh token is invalidated regardless of
+// whether the exchange succeeds, so a stolen-and-replayed token can only be
+// used once.
+func (ri *RefreshIssuer) Refresh(refreshToken string) (accessToken, newRefreshToken string, err error) {
+	record, ok, err := ri.Store.Get(refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+	if !ok {
+		return "", "", fmt.Errorf("unknown refresh token")
+	}
+	if err := ri.Store.Delete(refreshToken); err != nil {
+		return "", "", err
+	}
+	if record.Expired() {
+		return "", "", fmt.Errorf("refresh token expired at %s", record.ExpiresAt)
+	}
+
+	now := time.Now()
+	accessToken, err = ri.Signer.Sign(&AuthToken{
+		Issuer:    ri.Issuer,
+		Subject:   record.Username,
+		Audience:  ri.Audience,
+		Groups:    record.Groups,
+		Type:      TokenTypeAccess,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ri.AccessTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	// The rotated token inherits the original expiry, so rotation never
+	// extends a session past Lifetime.
+	newRefreshToken, err = newOpaqueID()
+	if err != nil {
+		return "", "", err
+	}
+	rotated := &RefreshRecord{
+		Username:  record.Username,
+		Groups:    record.Groups,
+		ExpiresAt: record.ExpiresAt,
+	}
+	if err := ri.Store.Put(newRefreshToken, rotated); err != nil {
+		return "", "", err
+	}
+	return accessToken, newRefreshToken, nil
+}
+
+// Revoke invalidates a refresh token so it can no longer be exchanged.
+func (ri *RefreshIssuer) Revoke(refreshToken string) error {
+	return ri.Store.Delete(refreshToken)
+}
+
+func newOpaqueID() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,92 @@
+package token
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+type fakeSigner struct {
+	calls int
+}
+
+func (s *fakeSigner) Sign(t *AuthToken) (string, error) {
+	s.calls++
+	return fmt.Sprintf("signed-%d-%s", s.calls, t.Subject), nil
+}
+
+func TestRefreshIssuerRefreshRotatesToken(t *testing.T) {
+	signer := &fakeSigner{}
+	ri := NewRefreshIssuer(NewMemoryStore(), signer, time.Hour, time.Minute)
+
+	refreshToken, err := ri.Issue("alice", []string{"admins"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	accessToken, newRefreshToken, err := ri.Refresh(refreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if accessToken == "" {
+		t.Error("Refresh returned an empty access token")
+	}
+	if newRefreshToken == "" || newRefreshToken == refreshToken {
+		t.Errorf("Refresh did not rotate the refresh token: got %q", newRefreshToken)
+	}
+}
+
+func TestRefreshIssuerRefreshInvalidatesOldToken(t *testing.T) {
+	signer := &fakeSigner{}
+	ri := NewRefreshIssuer(NewMemoryStore(), signer, time.Hour, time.Minute)
+
+	refreshToken, err := ri.Issue("alice", nil)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	if _, _, err := ri.Refresh(refreshToken); err != nil {
+		t.Fatalf("first Refresh: %v", err)
+	}
+
+	if _, _, err := ri.Refresh(refreshToken); err == nil {
+		t.Error("expected replaying an already-rotated refresh token to fail")
+	}
+}
+
+func TestRefreshIssuerRefreshRejectsExpired(t *testing.T) {
+	signer := &fakeSigner{}
+	ri := NewRefreshIssuer(NewMemoryStore(), signer, -time.Hour, time.Minute)
+
+	refreshToken, err := ri.Issue("alice", nil)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	if _, _, err := ri.Refresh(refreshToken); err == nil {
+		t.Error("expected Refresh to reject an already-expired refresh token")
+	}
+
+	if _, ok, err := ri.Store.Get(refreshToken); err != nil {
+		t.Fatalf("Get: %v", err)
+	} else if ok {
+		t.Error("expected the expired refresh token to be deleted, not just rejected")
+	}
+}
+
+func TestRefreshIssuerRevoke(t *testing.T) {
+	signer := &fakeSigner{}
+	ri := NewRefreshIssuer(NewMemoryStore(), signer, time.Hour, time.Minute)
+
+	refreshToken, err := ri.Issue("alice", nil)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if err := ri.Revoke(refreshToken); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	if _, _, err := ri.Refresh(refreshToken); err == nil {
+		t.Error("expected Refresh to fail for a revoked refresh token")
+	}
+}
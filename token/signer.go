@@ -49,6 +49,47 @@ func NewSigner(filename string) (Signer, error) {
 	return ecdsaSigner, nil
 }
 
+// NewSignerFromKeySet returns a Signer that always signs with the KeySet's
+// current active key, stamping its kid into the JWS header so a Verifier
+// backed by the same KeySet (or its published JWKS) can pick the matching
+// public key later, even after the key has rotated out as active.
+func NewSignerFromKeySet(keySet *KeySet) (Signer, error) {
+	return &keySetSigner{keySet: keySet}, nil
+}
+
+// keySetSigner signs with whichever key is active in a KeySet at the time
+// of signing, re-resolving it on every call so rotation takes effect
+// without restarting the process.
+type keySetSigner struct {
+	keySet *KeySet
+}
+
+// Sign implements Signer.
+func (s *keySetSigner) Sign(token *AuthToken) (string, error) {
+	active := s.keySet.Active()
+	if active == nil {
+		return "", fmt.Errorf("no active signing key")
+	}
+	rsaKey, ok := active.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("expected an RSA private key, but got a key of type %T", active.PrivateKey)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.PS512, Key: rsaKey}, &jose.SignerOptions{ExtraHeaders: map[jose.HeaderKey]interface{}{"kid": active.ID}})
+	if err != nil {
+		return "", err
+	}
+
+	tokenBytes, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	jws, err := signer.Sign(tokenBytes)
+	if err != nil {
+		return "", err
+	}
+	return jws.CompactSerialize()
+}
+
 // Sign an authentcation token and return the serialized JWS
 func (es *rsaSigner) Sign(token *AuthToken) (string, error) {
 	tokenBytes, err := json.Marshal(token)
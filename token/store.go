@@ -0,0 +1,64 @@
+package token
+
+import (
+	"sync"
+	"time"
+)
+
+// RefreshRecord is the server-side state associated with an opaque refresh
+// token: who it was issued to and when it stops being usable.
+type RefreshRecord struct {
+	Username  string
+	Groups    []string
+	ExpiresAt time.Time
+}
+
+// Expired reports whether the record's ExpiresAt has passed.
+func (r *RefreshRecord) Expired() bool {
+	return time.Now().After(r.ExpiresAt)
+}
+
+// RefreshStore persists refresh-token records so they survive process
+// restarts (depending on the backend) and can be revoked on demand.
+// Implementations must be safe for concurrent use.
+type RefreshStore interface {
+	// Put stores record under id, replacing any existing record.
+	Put(id string, record *RefreshRecord) error
+	// Get returns the record stored under id, or ok=false if absent.
+	Get(id string) (record *RefreshRecord, ok bool, err error)
+	// Delete removes the record stored under id, if any.
+	Delete(id string) error
+}
+
+// MemoryStore is a RefreshStore backed by an in-process map. Records do not
+// survive a restart; this is the default for single-replica deployments.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]*RefreshRecord
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]*RefreshRecord)}
+}
+
+func (s *MemoryStore) Put(id string, record *RefreshRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[id] = record
+	return nil
+}
+
+func (s *MemoryStore) Get(id string) (*RefreshRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[id]
+	return record, ok, nil
+}
+
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, id)
+	return nil
+}
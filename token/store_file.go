@@ -0,0 +1,72 @@
+package token
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// FileStore is a RefreshStore that persists all records as a single JSON
+// file, rewritten on every mutation. It is meant for single-replica
+// deployments that need refresh tokens to survive a process restart without
+// standing up a shared database.
+type FileStore struct {
+	mu      sync.Mutex
+	path    string
+	records map[string]*RefreshRecord
+}
+
+// NewFileStore loads (or creates) the JSON store at path.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, records: make(map[string]*RefreshRecord)}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(data, &s.records); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) Put(id string, record *RefreshRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[id] = record
+	return s.flushLocked()
+}
+
+func (s *FileStore) Get(id string) (*RefreshRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[id]
+	return record, ok, nil
+}
+
+func (s *FileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, id)
+	return s.flushLocked()
+}
+
+// flushLocked rewrites the backing file. Callers must hold s.mu.
+func (s *FileStore) flushLocked() error {
+	data, err := json.Marshal(s.records)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,12 @@
+// +build !redis
+
+package token
+
+import "fmt"
+
+// NewRedisStoreFromAddr is a stand-in for binaries built without the
+// "redis" tag, so --refresh-token-store=redis fails with an actionable
+// error instead of the flag silently not existing.
+func NewRedisStoreFromAddr(addr, prefix string) (RefreshStore, error) {
+	return nil, fmt.Errorf("redis refresh token store requested but this binary was built without the \"redis\" build tag")
+}
@@ -0,0 +1,110 @@
+package token
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// Verifier checks the signature on a serialized JWS and, if valid, returns
+// the AuthToken it carries.
+type Verifier interface {
+	// Verify checks the token's signature and expiry and returns its claims.
+	Verify(serializedToken string) (*AuthToken, error)
+}
+
+// rsaVerifier verifies JWS tokens signed with a single RSA public key.
+type rsaVerifier struct {
+	publicKey *rsa.PublicKey
+}
+
+// NewVerifier loads the RSA public key at filename+".pub" and returns a
+// Verifier that checks JWS signatures against it.
+func NewVerifier(filename string) (Verifier, error) {
+	key, err := ioutil.ReadFile(filename + ".pub")
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := LoadPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an RSA public key, but got a key of type %T", publicKey)
+	}
+
+	return &rsaVerifier{publicKey: rsaKey}, nil
+}
+
+// Verify implements Verifier.
+func (v *rsaVerifier) Verify(serializedToken string) (*AuthToken, error) {
+	jws, err := jose.ParseSigned(serializedToken)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := jws.Verify(v.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeAndCheckExpiry(payload)
+}
+
+// NewVerifierFromKeySet returns a Verifier that picks the public key
+// matching a token's "kid" header from keySet, including keys that have
+// rotated out as active but are still within their retention window.
+func NewVerifierFromKeySet(keySet *KeySet) Verifier {
+	return &keySetVerifier{keySet: keySet}
+}
+
+// keySetVerifier resolves the verification key by kid on every call, so
+// key rotation and retirement take effect without restarting the process.
+type keySetVerifier struct {
+	keySet *KeySet
+}
+
+// Verify implements Verifier.
+func (v *keySetVerifier) Verify(serializedToken string) (*AuthToken, error) {
+	jws, err := jose.ParseSigned(serializedToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, fmt.Errorf("expected exactly one signature, got %d", len(jws.Signatures))
+	}
+
+	kid := jws.Signatures[0].Header.KeyID
+	key, ok := v.keySet.Get(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key kid=%q", kid)
+	}
+	rsaKey, ok := key.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an RSA private key, but got a key of type %T", key.PrivateKey)
+	}
+
+	payload, err := jws.Verify(&rsaKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeAndCheckExpiry(payload)
+}
+
+func decodeAndCheckExpiry(payload []byte) (*AuthToken, error) {
+	token := &AuthToken{}
+	if err := json.Unmarshal(payload, token); err != nil {
+		return nil, err
+	}
+	if token.Expired() {
+		return nil, fmt.Errorf("token expired at %s", token.ExpiresAt)
+	}
+	return token, nil
+}